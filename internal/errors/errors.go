@@ -4,6 +4,7 @@ package errors
 import (
 	"errors"
 	"net"
+	"net/http"
 	"strings"
 )
 
@@ -37,17 +38,21 @@ const (
 	NetworkDNSError ErrorCategory = "NETWORK_DNS_ERROR"
 	NetworkTimeout  ErrorCategory = "NETWORK_TIMEOUT"
 
+	// Upstream concurrency errors
+	UpstreamQueueTimeout ErrorCategory = "UPSTREAM_QUEUE_TIMEOUT"
+
 	// Generic errors
 	InternalError ErrorCategory = "INTERNAL_ERROR"
 )
 
 // CategorizedError wraps an error with a category and additional context.
 type CategorizedError struct {
-	Category   ErrorCategory
-	Message    string
-	StatusCode int    // HTTP status code if applicable
-	Operation  string // e.g., "sts_exchange", "generate_id_token"
-	Err        error  // underlying error
+	Category     ErrorCategory
+	Message      string
+	StatusCode   int    // HTTP status code if applicable
+	Operation    string // e.g., "sts_exchange", "generate_id_token"
+	GoogleStatus string // upstream Google status, e.g., "PERMISSION_DENIED"
+	Err          error  // underlying error
 }
 
 func (e *CategorizedError) Error() string {
@@ -82,6 +87,12 @@ func (e *CategorizedError) WithStatusCode(code int) *CategorizedError {
 	return e
 }
 
+// WithGoogleStatus adds the upstream Google error status to the error.
+func (e *CategorizedError) WithGoogleStatus(status string) *CategorizedError {
+	e.GoogleStatus = status
+	return e
+}
+
 // CategorizeNetworkError determines the appropriate error category for network errors.
 func CategorizeNetworkError(err error) ErrorCategory {
 	if err == nil {
@@ -138,3 +149,26 @@ func GetStatusCode(err error) int {
 	}
 	return 0
 }
+
+// GetGoogleStatus extracts the upstream Google error status from an error if available.
+func GetGoogleStatus(err error) string {
+	var catErr *CategorizedError
+	if errors.As(err, &catErr) {
+		return catErr.GoogleStatus
+	}
+	return ""
+}
+
+// HTTPStatusCode maps an error to the HTTP status code returned to clients.
+// Upstream UNAUTHENTICATED maps to 401 and PERMISSION_DENIED maps to 403 so
+// callers can tell invalid credentials apart from missing authorization.
+func HTTPStatusCode(err error) int {
+	switch GetGoogleStatus(err) {
+	case "UNAUTHENTICATED":
+		return http.StatusUnauthorized
+	case "PERMISSION_DENIED":
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -165,3 +165,54 @@ func TestGetStatusCode(t *testing.T) {
 		t.Errorf("expected 0 for plain error, got %d", got)
 	}
 }
+
+func TestGetGoogleStatus(t *testing.T) {
+	catErr := New(IAMNon200, "error", nil).WithGoogleStatus("PERMISSION_DENIED")
+
+	if got := GetGoogleStatus(catErr); got != "PERMISSION_DENIED" {
+		t.Errorf("expected 'PERMISSION_DENIED', got %s", got)
+	}
+
+	// Non-categorized error
+	plainErr := fmt.Errorf("plain error")
+	if got := GetGoogleStatus(plainErr); got != "" {
+		t.Errorf("expected empty string for plain error, got %s", got)
+	}
+}
+
+func TestHTTPStatusCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{
+			name:     "unauthenticated",
+			err:      New(STSNon200, "error", nil).WithGoogleStatus("UNAUTHENTICATED"),
+			expected: 401,
+		},
+		{
+			name:     "permission denied",
+			err:      New(IAMNon200, "error", nil).WithGoogleStatus("PERMISSION_DENIED"),
+			expected: 403,
+		},
+		{
+			name:     "other google status",
+			err:      New(IAMNon200, "error", nil).WithGoogleStatus("INVALID_ARGUMENT"),
+			expected: 500,
+		},
+		{
+			name:     "plain error",
+			err:      fmt.Errorf("plain error"),
+			expected: 500,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HTTPStatusCode(tt.err); got != tt.expected {
+				t.Errorf("expected %d, got %d", tt.expected, got)
+			}
+		})
+	}
+}
@@ -16,8 +16,10 @@ import (
 	"github.com/UnitVectorY-Labs/gcpidentitytokenportal/internal/sanitizer"
 )
 
+// stsUrl is the STS token exchange endpoint, a variable so tests can override it
+var stsUrl = "https://sts.googleapis.com/v1/token"
+
 const (
-	stsUrl                   = "https://sts.googleapis.com/v1/token"
 	workloadIdentityPattern  = "//iam.googleapis.com/projects/%s/locations/global/workloadIdentityPools/%s/providers/%s"
 	serviceAccountUrlPattern = "https://iamcredentials.googleapis.com/v1/projects/-/serviceAccounts/%s:generateIdToken"
 
@@ -56,6 +58,37 @@ type IAMResponse struct {
 	Token string `json:"token"`
 }
 
+// upstreamSemaphore limits the number of concurrent STS/IAM call sequences.
+// A nil semaphore means no limit is applied.
+var upstreamSemaphore chan struct{}
+
+// SetMaxUpstreamConcurrency sets the maximum number of simultaneous upstream
+// token calls. A value of zero or less disables the limit. It must be called
+// before any tokens are requested.
+func SetMaxUpstreamConcurrency(n int) {
+	if n <= 0 {
+		upstreamSemaphore = nil
+		return
+	}
+	upstreamSemaphore = make(chan struct{}, n)
+}
+
+// acquireUpstream waits for a free upstream slot until the context is done.
+// The returned function must be called to release the slot.
+func acquireUpstream(ctx context.Context) (func(), error) {
+	sem := upstreamSemaphore
+	if sem == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case sem <- struct{}{}:
+		return func() { <-sem }, nil
+	case <-ctx.Done():
+		return nil, apperrors.New(apperrors.UpstreamQueueTimeout, "timed out waiting for upstream concurrency slot", ctx.Err())
+	}
+}
+
 // GetIdentityToken generates an identity token for the specified audience
 func GetIdentityToken(ctx context.Context, config *gcp_config.GoogleApplicationCredentials, audience string) (string, error) {
 	logger := logging.Default().WithComponent("token")
@@ -70,6 +103,17 @@ func GetIdentityToken(ctx context.Context, config *gcp_config.GoogleApplicationC
 		return "", catErr
 	}
 
+	queueStart := time.Now()
+	release, err := acquireUpstream(ctx)
+	if err != nil {
+		logger.Error(ctx, "upstream concurrency limit wait exceeded", logging.Fields{
+			"error_category": string(apperrors.GetCategory(err)),
+			"wait_ms":        time.Since(queueStart).Milliseconds(),
+		})
+		return "", err
+	}
+	defer release()
+
 	accessToken, err := exchangeToken(ctx, config, string(jwt))
 	if err != nil {
 		// Error already logged in exchangeToken
@@ -141,7 +185,8 @@ func exchangeToken(ctx context.Context, config *gcp_config.GoogleApplicationCred
 		
 		catErr := apperrors.New(apperrors.STSNon200, "STS returned non-OK status", nil).
 			WithOperation(operation).
-			WithStatusCode(resp.StatusCode)
+			WithStatusCode(resp.StatusCode).
+			WithGoogleStatus(status)
 		
 		logger.Error(ctx, "STS returned error", logging.Fields{
 			"error_category":   string(catErr.Category),
@@ -254,7 +299,8 @@ func generateIdentityToken(ctx context.Context, config *gcp_config.GoogleApplica
 		
 		catErr := apperrors.New(apperrors.IAMNon200, "IAM returned non-OK status", nil).
 			WithOperation(operation).
-			WithStatusCode(resp.StatusCode)
+			WithStatusCode(resp.StatusCode).
+			WithGoogleStatus(status)
 		
 		logger.Error(ctx, "IAM returned error", logging.Fields{
 			"error_category":    string(catErr.Category),
@@ -0,0 +1,158 @@
+package token
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	gcp_config "github.com/UnitVectorY-Labs/gcpidentitytokenportal/internal/config"
+	apperrors "github.com/UnitVectorY-Labs/gcpidentitytokenportal/internal/errors"
+)
+
+// writeTokenFile writes a placeholder Kubernetes token and returns credentials referencing it
+func writeTokenFile(t *testing.T) *gcp_config.GoogleApplicationCredentials {
+	t.Helper()
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("jwt"), 0o600); err != nil {
+		t.Fatalf("failed to write token file: %v", err)
+	}
+	config := &gcp_config.GoogleApplicationCredentials{}
+	config.CredentialSource.File = tokenFile
+	return config
+}
+
+func TestGetIdentityToken_UpstreamStatus(t *testing.T) {
+	tests := []struct {
+		name           string
+		stsStatus      int
+		stsBody        string
+		iamStatus      int
+		iamBody        string
+		expectedStatus int
+	}{
+		{
+			name:           "STS unauthenticated",
+			stsStatus:      http.StatusUnauthorized,
+			stsBody:        `{"error":{"code":401,"status":"UNAUTHENTICATED","message":"Token expired"}}`,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "IAM permission denied",
+			stsStatus:      http.StatusOK,
+			stsBody:        `{"access_token":"access","expires_in":3600,"token_type":"Bearer"}`,
+			iamStatus:      http.StatusForbidden,
+			iamBody:        `{"error":{"code":403,"status":"PERMISSION_DENIED","message":"Permission denied"}}`,
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.stsStatus)
+				w.Write([]byte(tt.stsBody))
+			}))
+			defer sts.Close()
+			iam := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.iamStatus)
+				w.Write([]byte(tt.iamBody))
+			}))
+			defer iam.Close()
+
+			originalStsUrl := stsUrl
+			stsUrl = sts.URL
+			defer func() { stsUrl = originalStsUrl }()
+
+			config := writeTokenFile(t)
+			config.ServiceAccountImpersonationURL = iam.URL + "/v1/projects/-/serviceAccounts/sa@example.com:generateIdToken"
+
+			_, err := GetIdentityToken(context.Background(), config, "https://api.example.com")
+			if err == nil {
+				t.Fatal("expected error from upstream")
+			}
+			if got := apperrors.HTTPStatusCode(err); got != tt.expectedStatus {
+				t.Errorf("expected HTTP status %d, got %d", tt.expectedStatus, got)
+			}
+		})
+	}
+}
+
+func TestAcquireUpstream_NoLimit(t *testing.T) {
+	SetMaxUpstreamConcurrency(0)
+
+	release, err := acquireUpstream(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	release()
+}
+
+func TestAcquireUpstream_LimitsConcurrency(t *testing.T) {
+	const limit = 2
+	SetMaxUpstreamConcurrency(limit)
+	defer SetMaxUpstreamConcurrency(0)
+
+	var active, maxActive int32
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			release, err := acquireUpstream(context.Background())
+			if err != nil {
+				t.Errorf("expected no error, got %v", err)
+				return
+			}
+			defer release()
+
+			current := atomic.AddInt32(&active, 1)
+			for {
+				seen := atomic.LoadInt32(&maxActive)
+				if current <= seen || atomic.CompareAndSwapInt32(&maxActive, seen, current) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			atomic.AddInt32(&active, -1)
+		}()
+	}
+	wg.Wait()
+
+	if maxActive > limit {
+		t.Errorf("expected at most %d concurrent upstream calls, got %d", limit, maxActive)
+	}
+	if maxActive == 0 {
+		t.Error("expected at least one upstream call to run")
+	}
+}
+
+func TestGetIdentityToken_QueueTimeout(t *testing.T) {
+	SetMaxUpstreamConcurrency(1)
+	defer SetMaxUpstreamConcurrency(0)
+
+	// Occupy the only slot so the next caller has to queue
+	release, err := acquireUpstream(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	defer release()
+
+	config := writeTokenFile(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err = GetIdentityToken(ctx, config, "https://api.example.com")
+	if err == nil {
+		t.Fatal("expected error when upstream slot is unavailable")
+	}
+	if got := apperrors.GetCategory(err); got != apperrors.UpstreamQueueTimeout {
+		t.Errorf("expected category %s, got %s", apperrors.UpstreamQueueTimeout, got)
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"embed"
 	"encoding/json"
@@ -10,6 +11,7 @@ import (
 	"os"
 	"runtime/debug"
 	"slices"
+	"strconv"
 
 	"cloud.google.com/go/compute/metadata"
 	"golang.org/x/oauth2"
@@ -47,17 +49,29 @@ func handleIndex(tmpl *template.Template, cfg Config) http.HandlerFunc {
 			return
 		}
 
-		err := tmpl.ExecuteTemplate(w, "index.html", cfg)
-		if err != nil {
-			requestID := logging.GetRequestID(r.Context())
-			logger.Error(r.Context(), "template execution error", logging.Fields{
-				"error": err.Error(),
-			})
-			http.Error(w, fmt.Sprintf("Internal Server Error. request_id=%s", requestID), http.StatusInternalServerError)
-		}
+		renderTemplate(w, r, logger, tmpl, "index.html", cfg)
 	}
 }
 
+// renderTemplate executes the named template into a buffer and only writes the
+// response once rendering succeeds, so a failure never produces a partial page
+func renderTemplate(w http.ResponseWriter, r *http.Request, logger *logging.Logger, tmpl *template.Template, name string, data any) {
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, name, data); err != nil {
+		requestID := logging.GetRequestID(r.Context())
+		logger.Error(r.Context(), "template execution error", logging.Fields{
+			"template": name,
+			"error":    err.Error(),
+		})
+		http.Error(w, fmt.Sprintf("Internal Server Error. request_id=%s", requestID), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	buf.WriteTo(w)
+}
+
 func handleToken(ctx context.Context, cfg Config, credentialsFile string, googleApplicationCredentials *gcp_config.GoogleApplicationCredentials) http.HandlerFunc {
 	logger := logging.Default().WithComponent("token")
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -95,12 +109,15 @@ func handleToken(ctx context.Context, cfg Config, credentialsFile string, google
 			idToken, err := token.GetIdentityToken(r.Context(), googleApplicationCredentials, audience)
 			if err != nil {
 				category := apperrors.GetCategory(err)
+				statusCode := apperrors.HTTPStatusCode(err)
 				logger.Error(r.Context(), "failed to get identity token", logging.Fields{
 					"error_category":     string(category),
+					"google_status":      apperrors.GetGoogleStatus(err),
+					"http_status":        statusCode,
 					"audience":           audience,
 					"uses_impersonation": true,
 				})
-				http.Error(w, fmt.Sprintf("Failed to get identity token. request_id=%s", requestID), http.StatusInternalServerError)
+				http.Error(w, fmt.Sprintf("Failed to get identity token. request_id=%s", requestID), statusCode)
 				return
 			}
 
@@ -290,6 +307,23 @@ func main() {
 		}
 	}
 
+	// Limit concurrent upstream STS/IAM calls if configured
+	maxUpstreamConcurrency := 0
+	if v := os.Getenv("MAX_UPSTREAM_CONCURRENCY"); v != "" {
+		maxUpstreamConcurrency, err = strconv.Atoi(v)
+		if err != nil || maxUpstreamConcurrency < 0 {
+			startupLogger.Error(ctx, "invalid MAX_UPSTREAM_CONCURRENCY", logging.Fields{
+				"value": v,
+			})
+			os.Exit(1)
+		}
+	}
+	token.SetMaxUpstreamConcurrency(maxUpstreamConcurrency)
+
+	startupLogger.Info(ctx, "upstream concurrency configured", logging.Fields{
+		"max_upstream_concurrency": maxUpstreamConcurrency,
+	})
+
 	// Determine mode
 	mode := "direct"
 	if usesImpersonation {
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandleIndex(t *testing.T) {
+	tmpl := template.Must(template.New("index.html").Parse(`<ul>{{range .Audiences}}<li>{{.}}</li>{{end}}</ul>`))
+	cfg := Config{Audiences: []string{"https://api.example.com"}}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	handleIndex(tmpl, cfg)(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "text/html; charset=utf-8" {
+		t.Errorf("expected HTML content type, got %q", got)
+	}
+	if !strings.Contains(rec.Body.String(), "<li>https://api.example.com</li>") {
+		t.Errorf("expected rendered audience, got %q", rec.Body.String())
+	}
+}
+
+func TestHandleIndex_MissingField(t *testing.T) {
+	tmpl := template.Must(template.New("index.html").Parse(`<h1>partial</h1>{{.Missing}}`))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	handleIndex(tmpl, Config{})(rec, req)
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("expected status %d, got %d", http.StatusInternalServerError, rec.Code)
+	}
+	if got := rec.Header().Get("Content-Type"); strings.HasPrefix(got, "text/html") {
+		t.Errorf("expected non-HTML content type for error, got %q", got)
+	}
+	if strings.Contains(rec.Body.String(), "partial") {
+		t.Errorf("expected no partial page output, got %q", rec.Body.String())
+	}
+}